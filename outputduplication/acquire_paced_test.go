@@ -0,0 +1,130 @@
+package outputduplication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kirides/go-d3d/d3d11"
+	"github.com/kirides/go-d3d/dxgi"
+)
+
+// fakeClock lets tests drive acquirePaced's deadline/repeat checks without
+// real sleeps; each call to now() advances by step.
+type fakeClock struct {
+	t    time.Time
+	step time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	c.t = c.t.Add(c.step)
+	return c.t
+}
+
+func TestAcquirePacedImmediateSuccess(t *testing.T) {
+	clock := &fakeClock{t: time.Now(), step: time.Millisecond}
+	stats := &CaptureStats{}
+	haveAnyFrame := false
+	var lastFrameAt time.Time
+
+	want := &d3d11.ID3D11Texture2D{}
+	acquire := func(timeoutMs uint) (*d3d11.ID3D11Texture2D, dxgi.DXGI_OUTDUPL_FRAME_INFO, func(), error) {
+		return want, dxgi.DXGI_OUTDUPL_FRAME_INFO{}, func() {}, nil
+	}
+
+	got, _, _, repeated, err := acquirePaced(CaptureConfig{}, stats, &haveAnyFrame, &lastFrameAt, clock.now, acquire, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repeated {
+		t.Fatal("expected repeated=false on first success")
+	}
+	if got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if stats.FramesAcquired != 1 {
+		t.Fatalf("FramesAcquired = %d, want 1", stats.FramesAcquired)
+	}
+	if !haveAnyFrame {
+		t.Fatal("expected haveAnyFrame to be set")
+	}
+}
+
+func TestAcquirePacedZeroIntervalTimesOutImmediately(t *testing.T) {
+	clock := &fakeClock{t: time.Now(), step: time.Millisecond}
+	stats := &CaptureStats{}
+	haveAnyFrame := false
+	var lastFrameAt time.Time
+
+	calls := 0
+	acquire := func(timeoutMs uint) (*d3d11.ID3D11Texture2D, dxgi.DXGI_OUTDUPL_FRAME_INFO, func(), error) {
+		calls++
+		return nil, dxgi.DXGI_OUTDUPL_FRAME_INFO{}, nil, ErrNoImageYet
+	}
+
+	_, _, _, _, err := acquirePaced(CaptureConfig{}, stats, &haveAnyFrame, &lastFrameAt, clock.now, acquire, 1000)
+	if err != ErrNoImageYet {
+		t.Fatalf("err = %v, want ErrNoImageYet", err)
+	}
+	if calls != 1 {
+		t.Fatalf("acquire called %d times, want exactly 1 with TargetInterval unset", calls)
+	}
+	if stats.FramesTimedOut != 1 {
+		t.Fatalf("FramesTimedOut = %d, want 1", stats.FramesTimedOut)
+	}
+}
+
+func TestAcquirePacedRetriesUntilDeadlineThenDrops(t *testing.T) {
+	clock := &fakeClock{t: time.Now(), step: 10 * time.Millisecond}
+	stats := &CaptureStats{}
+	haveAnyFrame := false
+	var lastFrameAt time.Time
+
+	cfg := CaptureConfig{TargetInterval: 25 * time.Millisecond, PollInterval: time.Millisecond}
+	acquire := func(timeoutMs uint) (*d3d11.ID3D11Texture2D, dxgi.DXGI_OUTDUPL_FRAME_INFO, func(), error) {
+		return nil, dxgi.DXGI_OUTDUPL_FRAME_INFO{}, nil, ErrNoImageYet
+	}
+
+	_, _, _, repeated, err := acquirePaced(cfg, stats, &haveAnyFrame, &lastFrameAt, clock.now, acquire, 1000)
+	if err != ErrNoImageYet {
+		t.Fatalf("err = %v, want ErrNoImageYet", err)
+	}
+	if repeated {
+		t.Fatal("expected repeated=false without ForceRefreshTimeout")
+	}
+	if stats.FramesDropped != 1 {
+		t.Fatalf("FramesDropped = %d, want 1", stats.FramesDropped)
+	}
+	if stats.FramesTimedOut == 0 {
+		t.Fatal("expected at least one FramesTimedOut before the deadline tripped")
+	}
+}
+
+func TestAcquirePacedForceRefreshRepeats(t *testing.T) {
+	clock := &fakeClock{t: time.Now(), step: 10 * time.Millisecond}
+	stats := &CaptureStats{}
+	haveAnyFrame := true
+	lastFrameAt := clock.t
+
+	cfg := CaptureConfig{
+		TargetInterval:      time.Second,
+		PollInterval:        time.Millisecond,
+		ForceRefreshTimeout: 15 * time.Millisecond,
+	}
+	acquire := func(timeoutMs uint) (*d3d11.ID3D11Texture2D, dxgi.DXGI_OUTDUPL_FRAME_INFO, func(), error) {
+		return nil, dxgi.DXGI_OUTDUPL_FRAME_INFO{}, nil, ErrNoImageYet
+	}
+
+	_, _, cleanup, repeated, err := acquirePaced(cfg, stats, &haveAnyFrame, &lastFrameAt, clock.now, acquire, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !repeated {
+		t.Fatal("expected repeated=true once ForceRefreshTimeout elapses")
+	}
+	if cleanup == nil {
+		t.Fatal("expected a non-nil cleanup func")
+	}
+	if stats.FramesRepeated != 1 {
+		t.Fatalf("FramesRepeated = %d, want 1", stats.FramesRepeated)
+	}
+}
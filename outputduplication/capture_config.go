@@ -0,0 +1,48 @@
+package outputduplication
+
+import "time"
+
+// CaptureConfig controls Snapshot's frame-pacing behavior. The zero value
+// (TargetInterval == 0) preserves the original behavior: a single
+// AcquireNextFrame call that returns ErrNoImageYet immediately on timeout.
+type CaptureConfig struct {
+	// TargetInterval is the desired wall-clock spacing between frames, e.g.
+	// time.Second/60 for a 60fps capture loop. When set, Snapshot polls
+	// AcquireNextFrame in short increments (PollInterval) until either a new
+	// frame arrives or TargetInterval has elapsed since the call started,
+	// instead of returning ErrNoImageYet on the first DXGI timeout.
+	TargetInterval time.Duration
+	// PollInterval is the AcquireNextFrame timeout used for each internal
+	// polling attempt while waiting out TargetInterval. Defaults to 8ms if
+	// zero and TargetInterval != 0.
+	PollInterval time.Duration
+	// ForceRefreshTimeout re-emits the previous frame's staged texture,
+	// unchanged, if no new AccumulatedFrames show up within this duration -
+	// useful for static desktops where DXGI simply stops producing frames.
+	// Zero disables repeat-emission; Snapshot returns ErrNoImageYet instead
+	// once TargetInterval elapses. Only consulted while TargetInterval is
+	// non-zero; with TargetInterval == 0 no frame is ever repeated.
+	ForceRefreshTimeout time.Duration
+}
+
+// CaptureStats accumulates frame-pacing outcomes across the lifetime of an
+// OutputDuplicator. Read via OutputDuplicator.Stats(); not reset between calls.
+type CaptureStats struct {
+	// FramesAcquired counts frames delivered via a successful AcquireNextFrame.
+	FramesAcquired uint64
+	// FramesDropped counts Snapshot calls that exhausted TargetInterval
+	// without a new frame or a repeat being available.
+	FramesDropped uint64
+	// FramesTimedOut counts individual AcquireNextFrame polls that produced
+	// no new frame (DXGI_ERROR_WAIT_TIMEOUT, or an update with
+	// AccumulatedFrames == 0), whether or not the overall call eventually succeeded.
+	FramesTimedOut uint64
+	// FramesRepeated counts Snapshot calls that re-emitted the previous
+	// frame's staged texture because of ForceRefreshTimeout.
+	FramesRepeated uint64
+}
+
+// Stats returns the accumulated CaptureStats for this OutputDuplicator.
+func (dup *OutputDuplicator) Stats() CaptureStats {
+	return dup.stats
+}
@@ -0,0 +1,407 @@
+package outputduplication
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/kirides/go-d3d"
+	"github.com/kirides/go-d3d/d3d11"
+	"github.com/kirides/go-d3d/dxgi"
+)
+
+// cursorGPUPipeline renders the current pointer shape as a textured quad in
+// a single draw call, replacing the img.At/img.Set per-pixel CPU loop in
+// drawPointer for callers that opt into DrawPointerGPU.
+type cursorGPUPipeline struct {
+	vs         *d3d11.ID3D11VertexShader
+	psAlpha    *d3d11.ID3D11PixelShader // color / masked-color: straight alpha blend
+	psMono     *d3d11.ID3D11PixelShader // monochrome: XOR-against-background
+	sampler    *d3d11.ID3D11SamplerState
+	blendAlpha *d3d11.ID3D11BlendState
+	quadCB     *d3d11.ID3D11Buffer // float4 PosSizePx + float4 UVRect, in register(b0) of the VS
+
+	shapeTex *d3d11.ID3D11Texture2D // DYNAMIC, re-written whenever the shape changes
+	shapeSRV *d3d11.ID3D11ShaderResourceView
+	shapeW   int
+	shapeH   int
+
+	bgTex *d3d11.ID3D11Texture2D // DEFAULT, holds a copy of the pixels under the cursor for the XOR pass
+	bgSRV *d3d11.ID3D11ShaderResourceView
+	bgW   int
+	bgH   int
+
+	rtv       *d3d11.ID3D11RenderTargetView
+	rtvTarget *d3d11.ID3D11Texture2D // texture rtv was created for; rebuilt if this changes
+}
+
+// quadVertexShaderHLSL draws a quad covering [cbPos.xy, cbPos.xy+cbPos.zw] in
+// pixel space (PosSizePx), converted to NDC using ViewportPx (register(b1):
+// viewport width/height). UVRect selects the sub-rectangle of the shape/background
+// textures to sample, so a cursor clipped against the target bounds (see
+// clampCursorRect) samples the matching clipped region instead of stretching
+// the whole texture into a smaller quad. Vertices come from SV_VertexID, same
+// trick as the tonemap full-screen triangle.
+const quadVertexShaderHLSL = `
+cbuffer QuadRect : register(b0) { float4 PosSizePx; float4 UVRect; }
+cbuffer Viewport : register(b1) { float2 ViewportPx; float2 _pad; }
+
+void main(uint id : SV_VertexID, out float4 pos : SV_POSITION, out float2 uv : TEXCOORD0) {
+    float2 corner = float2(id & 1, (id >> 1) & 1);
+    uv = UVRect.xy + corner * UVRect.zw;
+    float2 px = PosSizePx.xy + corner * PosSizePx.zw;
+    float2 ndc = (px / ViewportPx) * float2(2, -2) + float2(-1, 1);
+    pos = float4(ndc, 0, 1);
+}
+`
+
+const cursorAlphaPixelShaderHLSL = `
+Texture2D<float4> Shape : register(t0);
+SamplerState Samp : register(s0);
+
+float4 main(float4 pos : SV_POSITION, float2 uv : TEXCOORD0) : SV_TARGET {
+    return Shape.Sample(Samp, uv);
+}
+`
+
+// cursorMonoPixelShaderHLSL implements the AND/XOR monochrome cursor rule.
+// updatePointer encodes the two opaque (andBit && xorBit, or !andBit &&
+// xorBit) cases with distinct marker colors: Shape.rgb == (1,1,1) is the
+// "Inverted (white)" case, which must unconditionally invert the background
+// regardless of its brightness; Shape.rgb == (0,0,0) is the "Inverted
+// (adaptive color)" case, which is drawn black where black contrasts with the
+// background and inverted to white where it would not (the per-pixel form of
+// drawPointer's light/dark choice). Shape.a encodes "opaque".
+// Background is the pixels currently under the cursor, sampled in a prior
+// pass into Bg.
+const cursorMonoPixelShaderHLSL = `
+Texture2D<float4> Shape : register(t0);
+Texture2D<float4> Bg : register(t1);
+SamplerState Samp : register(s0);
+
+float4 main(float4 pos : SV_POSITION, float2 uv : TEXCOORD0) : SV_TARGET {
+    float4 shape = Shape.Sample(Samp, uv);
+    if (shape.a == 0) discard;
+    float3 bg = Bg.Sample(Samp, uv).rgb;
+    float3 xored;
+    if (all(shape.rgb > 0.5)) {
+        xored = 1.0 - bg;
+    } else {
+        xored = abs(bg - shape.rgb) > 0.5 ? shape.rgb : (1.0 - shape.rgb);
+    }
+    return float4(xored, 1);
+}
+`
+
+func (dup *OutputDuplicator) initCursorGPU() error {
+	if dup.cursorGPU != nil {
+		return nil
+	}
+
+	vsBlob, err := d3d11.CompileShader([]byte(quadVertexShaderHLSL), "main", "vs_5_0")
+	if err != nil {
+		return fmt.Errorf("failed to compile cursor vertex shader. %w", err)
+	}
+	psaBlob, err := d3d11.CompileShader([]byte(cursorAlphaPixelShaderHLSL), "main", "ps_5_0")
+	if err != nil {
+		return fmt.Errorf("failed to compile cursor alpha pixel shader. %w", err)
+	}
+	psmBlob, err := d3d11.CompileShader([]byte(cursorMonoPixelShaderHLSL), "main", "ps_5_0")
+	if err != nil {
+		return fmt.Errorf("failed to compile cursor mono pixel shader. %w", err)
+	}
+
+	cg := &cursorGPUPipeline{}
+	if hr := d3d.HRESULT(dup.device.CreateVertexShader(vsBlob, nil, &cg.vs)); hr.Failed() {
+		return fmt.Errorf("failed to CreateVertexShader. %w", hr)
+	}
+	if hr := d3d.HRESULT(dup.device.CreatePixelShader(psaBlob, nil, &cg.psAlpha)); hr.Failed() {
+		return fmt.Errorf("failed to CreatePixelShader (alpha). %w", hr)
+	}
+	if hr := d3d.HRESULT(dup.device.CreatePixelShader(psmBlob, nil, &cg.psMono)); hr.Failed() {
+		return fmt.Errorf("failed to CreatePixelShader (mono). %w", hr)
+	}
+	if hr := d3d.HRESULT(dup.device.CreateSamplerState(&d3d11.D3D11_SAMPLER_DESC{
+		Filter:         d3d11.D3D11_FILTER_MIN_MAG_MIP_POINT,
+		AddressU:       d3d11.D3D11_TEXTURE_ADDRESS_CLAMP,
+		AddressV:       d3d11.D3D11_TEXTURE_ADDRESS_CLAMP,
+		AddressW:       d3d11.D3D11_TEXTURE_ADDRESS_CLAMP,
+		ComparisonFunc: d3d11.D3D11_COMPARISON_NEVER,
+	}, &cg.sampler)); hr.Failed() {
+		return fmt.Errorf("failed to CreateSamplerState. %w", hr)
+	}
+
+	blendDesc := d3d11.D3D11_BLEND_DESC{}
+	blendDesc.RenderTarget[0].BlendEnable = 1
+	blendDesc.RenderTarget[0].SrcBlend = d3d11.D3D11_BLEND_SRC_ALPHA
+	blendDesc.RenderTarget[0].DestBlend = d3d11.D3D11_BLEND_INV_SRC_ALPHA
+	blendDesc.RenderTarget[0].BlendOp = d3d11.D3D11_BLEND_OP_ADD
+	blendDesc.RenderTarget[0].SrcBlendAlpha = d3d11.D3D11_BLEND_ONE
+	blendDesc.RenderTarget[0].DestBlendAlpha = d3d11.D3D11_BLEND_ZERO
+	blendDesc.RenderTarget[0].BlendOpAlpha = d3d11.D3D11_BLEND_OP_ADD
+	blendDesc.RenderTarget[0].RenderTargetWriteMask = d3d11.D3D11_COLOR_WRITE_ENABLE_ALL
+	if hr := d3d.HRESULT(dup.device.CreateBlendState(&blendDesc, &cg.blendAlpha)); hr.Failed() {
+		return fmt.Errorf("failed to CreateBlendState. %w", hr)
+	}
+
+	if hr := d3d.HRESULT(dup.device.CreateBuffer(&d3d11.D3D11_BUFFER_DESC{
+		ByteWidth: 32, // float4 PosSizePx + float4 UVRect
+		Usage:     d3d11.D3D11_USAGE_DEFAULT,
+		BindFlags: d3d11.D3D11_BIND_CONSTANT_BUFFER,
+	}, nil, &cg.quadCB)); hr.Failed() {
+		return fmt.Errorf("failed to CreateBuffer (quad cbuffer). %w", hr)
+	}
+
+	dup.cursorGPU = cg
+	return nil
+}
+
+// uploadCursorShape (re)creates the dynamic shape texture when the pointer
+// size changed, and maps/writes the RGBA pixels, matching the format
+// updatePointer already decodes into pointerInfo.shapeOutBuffer.
+func (dup *OutputDuplicator) uploadCursorShape() error {
+	cg := dup.cursorGPU
+	w, h := int(dup.pointerInfo.size.X), int(dup.pointerInfo.size.Y)
+	if w == 0 || h == 0 {
+		return nil
+	}
+
+	if cg.shapeTex == nil || cg.shapeW != w || cg.shapeH != h {
+		if cg.shapeSRV != nil {
+			cg.shapeSRV.Release()
+			cg.shapeSRV = nil
+		}
+		if cg.shapeTex != nil {
+			cg.shapeTex.Release()
+			cg.shapeTex = nil
+		}
+		desc := d3d11.D3D11_TEXTURE2D_DESC{
+			Width:          uint32(w),
+			Height:         uint32(h),
+			MipLevels:      1,
+			ArraySize:      1,
+			Format:         dxgi.DXGI_FORMAT_R8G8B8A8_UNORM,
+			Usage:          d3d11.D3D11_USAGE_DYNAMIC,
+			BindFlags:      d3d11.D3D11_BIND_SHADER_RESOURCE,
+			CPUAccessFlags: d3d11.D3D11_CPU_ACCESS_WRITE,
+		}
+		desc.SampleDesc.Count = 1
+		if hr := d3d.HRESULT(dup.device.CreateTexture2D(&desc, &cg.shapeTex)); hr.Failed() {
+			return fmt.Errorf("failed to create cursor shape texture. %w", hr)
+		}
+		if hr := d3d.HRESULT(dup.device.CreateShaderResourceView(cg.shapeTex, nil, &cg.shapeSRV)); hr.Failed() {
+			return fmt.Errorf("failed to CreateShaderResourceView (cursor shape). %w", hr)
+		}
+		cg.shapeW, cg.shapeH = w, h
+	}
+
+	var mapped d3d11.D3D11_MAPPED_SUBRESOURCE
+	if hr := d3d.HRESULT(dup.deviceCtx.Map(cg.shapeTex, 0, d3d11.D3D11_MAP_WRITE_DISCARD, 0, &mapped)); hr.Failed() {
+		return fmt.Errorf("failed to Map cursor shape texture. %w", hr)
+	}
+	defer dup.deviceCtx.Unmap(cg.shapeTex, 0)
+
+	pix := dup.pointerInfo.shapeOutBuffer.Pix
+	rowBytes := w * 4
+	dst := unsafe.Slice((*byte)(mapped.PData), int(mapped.RowPitch)*h)
+	for y := 0; y < h; y++ {
+		copy(dst[y*int(mapped.RowPitch):], pix[y*rowBytes:(y+1)*rowBytes])
+	}
+
+	return nil
+}
+
+// clampCursorRect clamps a w x h rectangle positioned at (x, y) against a
+// target of size targetW x targetH, returning the sub-rectangle that
+// actually overlaps the target (dstX, dstY, dstW, dstH) and the offset into
+// the source that sub-rectangle starts at (srcX, srcY). x/y are frequently
+// negative near the top/left screen edge - analyzeBackgroundBrightness
+// already clamps for the same reason on the CPU path - and casting that
+// straight to uint32 for a D3D11_BOX produces a huge, invalid box instead of
+// clipping. dstW/dstH are 0 if the rectangle doesn't overlap the target at all.
+func clampCursorRect(x, y, w, h, targetW, targetH int) (dstX, dstY, dstW, dstH, srcX, srcY int) {
+	dstX, dstY, dstW, dstH = x, y, w, h
+	if dstX < 0 {
+		srcX = -dstX
+		dstW += dstX
+		dstX = 0
+	}
+	if dstY < 0 {
+		srcY = -dstY
+		dstH += dstY
+		dstY = 0
+	}
+	if dstX+dstW > targetW {
+		dstW = targetW - dstX
+	}
+	if dstY+dstH > targetH {
+		dstH = targetH - dstY
+	}
+	if dstW < 0 {
+		dstW = 0
+	}
+	if dstH < 0 {
+		dstH = 0
+	}
+	return dstX, dstY, dstW, dstH, srcX, srcY
+}
+
+// drawPointerGPU renders the current cursor directly onto target (typically
+// dup.renderTex, once SnapshotTexture has produced one) via a single
+// textured-quad draw call instead of the CPU img.At/img.Set loop in
+// drawPointer. For the monochrome "inverted" cursor type it first copies the
+// pixels under the cursor into a scratch texture so the pixel shader can
+// evaluate the XOR-against-background rule.
+func (dup *OutputDuplicator) drawPointerGPU(target *d3d11.ID3D11Texture2D) error {
+	if !dup.pointerInfo.visible || dup.pointerInfo.shapeOutBuffer == nil {
+		return nil
+	}
+	if err := dup.initCursorGPU(); err != nil {
+		return err
+	}
+	if err := dup.uploadCursorShape(); err != nil {
+		return err
+	}
+
+	cg := dup.cursorGPU
+	dstX, dstY, dstW, dstH, srcX, srcY := clampCursorRect(
+		int(dup.pointerInfo.pos.X), int(dup.pointerInfo.pos.Y), cg.shapeW, cg.shapeH,
+		int(dup.size.X), int(dup.size.Y))
+	if dstW <= 0 || dstH <= 0 {
+		// Cursor is entirely off the captured desktop; nothing to draw.
+		return nil
+	}
+
+	if cg.rtv == nil || cg.rtvTarget != target {
+		if cg.rtv != nil {
+			cg.rtv.Release()
+		}
+		if hr := d3d.HRESULT(dup.device.CreateRenderTargetView(target, nil, &cg.rtv)); hr.Failed() {
+			return fmt.Errorf("failed to CreateRenderTargetView (cursor target). %w", hr)
+		}
+		cg.rtvTarget = target
+	}
+
+	quad := [8]float32{
+		float32(dstX), float32(dstY), float32(dstW), float32(dstH),
+		float32(srcX) / float32(cg.shapeW), float32(srcY) / float32(cg.shapeH),
+		float32(dstW) / float32(cg.shapeW), float32(dstH) / float32(cg.shapeH),
+	}
+	dup.deviceCtx.UpdateSubresource(cg.quadCB, 0, nil, unsafe.Pointer(&quad[0]), 0, 0)
+
+	viewport := [4]float32{float32(dup.size.X), float32(dup.size.Y), 0, 0}
+	var viewportCB *d3d11.ID3D11Buffer
+	if hr := d3d.HRESULT(dup.device.CreateBuffer(&d3d11.D3D11_BUFFER_DESC{
+		ByteWidth: 16, Usage: d3d11.D3D11_USAGE_DEFAULT, BindFlags: d3d11.D3D11_BIND_CONSTANT_BUFFER,
+	}, &d3d11.D3D11_SUBRESOURCE_DATA{PSysMem: unsafe.Pointer(&viewport[0])}, &viewportCB)); hr.Failed() {
+		return fmt.Errorf("failed to CreateBuffer (viewport cbuffer). %w", hr)
+	}
+	defer viewportCB.Release()
+
+	ps := cg.psAlpha
+	srvs := []*d3d11.ID3D11ShaderResourceView{cg.shapeSRV}
+
+	if dup.pointerInfo.isMonochrome {
+		if err := dup.updateCursorBackground(target, cg, dstX, dstY, dstW, dstH, srcX, srcY); err != nil {
+			return err
+		}
+		ps = cg.psMono
+		srvs = append(srvs, cg.bgSRV)
+	}
+
+	dup.deviceCtx.OMSetRenderTargets([]*d3d11.ID3D11RenderTargetView{cg.rtv}, nil)
+	dup.deviceCtx.OMSetBlendState(cg.blendAlpha, nil, 0xFFFFFFFF)
+	dup.deviceCtx.VSSetShader(cg.vs, nil)
+	dup.deviceCtx.VSSetConstantBuffers(0, []*d3d11.ID3D11Buffer{cg.quadCB, viewportCB})
+	dup.deviceCtx.PSSetShader(ps, nil)
+	dup.deviceCtx.PSSetShaderResources(0, srvs)
+	dup.deviceCtx.PSSetSamplers(0, []*d3d11.ID3D11SamplerState{cg.sampler})
+	dup.deviceCtx.IASetPrimitiveTopology(d3d11.D3D11_PRIMITIVE_TOPOLOGY_TRIANGLESTRIP)
+	dup.deviceCtx.Draw(4, 0)
+
+	return nil
+}
+
+// updateCursorBackground copies the pixels currently under the cursor quad
+// into cg.bgTex/bgSRV, the "prior pass" the monochrome XOR pixel shader
+// samples. dstX/dstY/dstW/dstH/srcX/srcY are the clampCursorRect result from
+// drawPointerGPU: dstX/dstY/dstW/dstH address target (already clamped to its
+// bounds), srcX/srcY is where that clamped region lands inside the
+// full-sized bgTex so it lines up with the quad's UVRect-adjusted sampling.
+func (dup *OutputDuplicator) updateCursorBackground(target *d3d11.ID3D11Texture2D, cg *cursorGPUPipeline, dstX, dstY, dstW, dstH, srcX, srcY int) error {
+	w, h := cg.shapeW, cg.shapeH
+	if cg.bgTex == nil || cg.bgW != w || cg.bgH != h {
+		if cg.bgSRV != nil {
+			cg.bgSRV.Release()
+			cg.bgSRV = nil
+		}
+		if cg.bgTex != nil {
+			cg.bgTex.Release()
+			cg.bgTex = nil
+		}
+		desc := d3d11.D3D11_TEXTURE2D_DESC{
+			Width: uint32(w), Height: uint32(h),
+			MipLevels: 1, ArraySize: 1,
+			Format:    dxgi.DXGI_FORMAT_R8G8B8A8_UNORM,
+			Usage:     d3d11.D3D11_USAGE_DEFAULT,
+			BindFlags: d3d11.D3D11_BIND_SHADER_RESOURCE,
+		}
+		desc.SampleDesc.Count = 1
+		if hr := d3d.HRESULT(dup.device.CreateTexture2D(&desc, &cg.bgTex)); hr.Failed() {
+			return fmt.Errorf("failed to create cursor background texture. %w", hr)
+		}
+		if hr := d3d.HRESULT(dup.device.CreateShaderResourceView(cg.bgTex, nil, &cg.bgSRV)); hr.Failed() {
+			return fmt.Errorf("failed to CreateShaderResourceView (cursor background). %w", hr)
+		}
+		cg.bgW, cg.bgH = w, h
+	}
+
+	box := d3d11.D3D11_BOX{
+		Left:   uint32(dstX),
+		Top:    uint32(dstY),
+		Front:  0,
+		Right:  uint32(dstX + dstW),
+		Bottom: uint32(dstY + dstH),
+		Back:   1,
+	}
+	dup.deviceCtx.CopySubresourceRegion2D(cg.bgTex, 0, uint32(srcX), uint32(srcY), 0, target, 0, &box)
+	return nil
+}
+
+func (cg *cursorGPUPipeline) Release() {
+	if cg == nil {
+		return
+	}
+	if cg.rtv != nil {
+		cg.rtv.Release()
+	}
+	if cg.bgSRV != nil {
+		cg.bgSRV.Release()
+	}
+	if cg.bgTex != nil {
+		cg.bgTex.Release()
+	}
+	if cg.shapeSRV != nil {
+		cg.shapeSRV.Release()
+	}
+	if cg.shapeTex != nil {
+		cg.shapeTex.Release()
+	}
+	if cg.quadCB != nil {
+		cg.quadCB.Release()
+	}
+	if cg.blendAlpha != nil {
+		cg.blendAlpha.Release()
+	}
+	if cg.sampler != nil {
+		cg.sampler.Release()
+	}
+	if cg.psMono != nil {
+		cg.psMono.Release()
+	}
+	if cg.psAlpha != nil {
+		cg.psAlpha.Release()
+	}
+	if cg.vs != nil {
+		cg.vs.Release()
+	}
+}
@@ -0,0 +1,355 @@
+package outputduplication
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"unsafe"
+
+	"github.com/kirides/go-d3d"
+	"github.com/kirides/go-d3d/d3d11"
+	"github.com/kirides/go-d3d/dxgi"
+)
+
+// ToneMapMode selects how an HDR (DXGI_FORMAT_R16G16B16A16_FLOAT) frame is
+// mapped before being handed back by GetImageFloat/GetImageNRGBA64.
+type ToneMapMode int
+
+const (
+	// ToneMapPassthrough leaves scRGB linear FP16 values untouched.
+	ToneMapPassthrough ToneMapMode = iota
+	// ToneMapReinhard applies the simple Reinhard operator (c / (1 + c)) per channel.
+	ToneMapReinhard
+	// ToneMapPQRec709 applies a BT.2390-style soft-knee highlight roll-off to
+	// the linear scRGB values DuplicateOutput1 hands back, compressing
+	// anything above SDR reference white (1.0) into [0, 1] instead of
+	// clipping it. Despite the name there is no PQ (ST.2084) decode involved:
+	// DXGI_FORMAT_R16G16B16A16_FLOAT capture is always linear scRGB, never
+	// PQ-encoded.
+	ToneMapPQRec709
+)
+
+// ImageRGBAF16 holds raw DXGI_FORMAT_R16G16B16A16_FLOAT pixel data, 8 bytes
+// per pixel (R, G, B, A half-floats, in that order), row-major with no
+// padding - the FP16 analog of image.RGBA.
+type ImageRGBAF16 struct {
+	Pix    []byte
+	Stride int
+	Rect   image.Rectangle
+}
+
+// NewImageRGBAF16 allocates an ImageRGBAF16 with the given bounds.
+func NewImageRGBAF16(r image.Rectangle) *ImageRGBAF16 {
+	return &ImageRGBAF16{
+		Pix:    make([]byte, r.Dx()*r.Dy()*8),
+		Stride: r.Dx() * 8,
+		Rect:   r,
+	}
+}
+
+// toneMapper owns the full-screen-triangle pipeline used to resolve the raw
+// HDR desktop texture into dup.hdrOutputTex.
+type toneMapper struct {
+	vs      *d3d11.ID3D11VertexShader
+	ps      *d3d11.ID3D11PixelShader
+	sampler *d3d11.ID3D11SamplerState
+	modeCB  *d3d11.ID3D11Buffer
+	srv     *d3d11.ID3D11ShaderResourceView
+	rtv     *d3d11.ID3D11RenderTargetView
+}
+
+// toneMapPixelShaderHLSL implements all three modes behind a mode constant so
+// switching ToneMap at runtime doesn't require recompiling/swapping shaders.
+// Mirrors the textbook Reinhard op and a BT.2390-style soft-knee rolloff
+// applied directly to linear scRGB (DuplicateOutput1's FP16 output is always
+// linear, never PQ-encoded, so there is no EOTF to invert here).
+const toneMapPixelShaderHLSL = `
+cbuffer ToneMapMode : register(b0) { uint Mode; uint3 _pad; }
+Texture2D<float4> Src : register(t0);
+SamplerState Samp : register(s0);
+
+float3 Reinhard(float3 c) { return c / (1.0 + c); }
+
+float3 RolloffRec709(float3 c) {
+    // BT.2390-style soft-knee: pass linear values through unchanged up to
+    // the knee, then compress everything brighter (including values above
+    // SDR reference white, 1.0) into [knee, 1.0) with a smooth shoulder
+    // instead of clipping.
+    const float knee = 0.5;
+    float3 x = max(c, 0.0);
+    float3 t = max(x - knee, 0.0) / (1.0 - knee);
+    float3 shoulder = knee + (1.0 - knee) * (t / (1.0 + t));
+    return lerp(x, shoulder, step(knee, x));
+}
+
+float4 main(float4 pos : SV_POSITION, float2 uv : TEXCOORD0) : SV_TARGET {
+    float4 c = Src.Sample(Samp, uv);
+    float3 mapped = c.rgb;
+    if (Mode == 1) mapped = Reinhard(c.rgb);
+    else if (Mode == 2) mapped = saturate(RolloffRec709(c.rgb));
+    return float4(mapped, c.a);
+}
+`
+
+const fullscreenTriangleVertexShaderHLSL = `
+void main(uint id : SV_VertexID, out float4 pos : SV_POSITION, out float2 uv : TEXCOORD0) {
+    uv = float2((id << 1) & 2, id & 2);
+    pos = float4(uv * float2(2, -2) + float2(-1, 1), 0, 1);
+}
+`
+
+// initToneMapper lazily compiles the tone-mapping pipeline and binds it to
+// dup.hdrInputTex/dup.hdrOutputTex. It runs once: the views it creates refer
+// to the textures present at that first call and are not rebuilt if those
+// textures are later replaced.
+func (dup *OutputDuplicator) initToneMapper() error {
+	if dup.toneMapper != nil {
+		return nil
+	}
+
+	vsBlob, err := d3d11.CompileShader([]byte(fullscreenTriangleVertexShaderHLSL), "main", "vs_5_0")
+	if err != nil {
+		return fmt.Errorf("failed to compile tonemap vertex shader. %w", err)
+	}
+	psBlob, err := d3d11.CompileShader([]byte(toneMapPixelShaderHLSL), "main", "ps_5_0")
+	if err != nil {
+		return fmt.Errorf("failed to compile tonemap pixel shader. %w", err)
+	}
+
+	tm := &toneMapper{}
+	if hr := d3d.HRESULT(dup.device.CreateVertexShader(vsBlob, nil, &tm.vs)); hr.Failed() {
+		return fmt.Errorf("failed to CreateVertexShader. %w", hr)
+	}
+	if hr := d3d.HRESULT(dup.device.CreatePixelShader(psBlob, nil, &tm.ps)); hr.Failed() {
+		return fmt.Errorf("failed to CreatePixelShader. %w", hr)
+	}
+	if hr := d3d.HRESULT(dup.device.CreateSamplerState(&d3d11.D3D11_SAMPLER_DESC{
+		Filter:         d3d11.D3D11_FILTER_MIN_MAG_MIP_POINT,
+		AddressU:       d3d11.D3D11_TEXTURE_ADDRESS_CLAMP,
+		AddressV:       d3d11.D3D11_TEXTURE_ADDRESS_CLAMP,
+		AddressW:       d3d11.D3D11_TEXTURE_ADDRESS_CLAMP,
+		ComparisonFunc: d3d11.D3D11_COMPARISON_NEVER,
+	}, &tm.sampler)); hr.Failed() {
+		return fmt.Errorf("failed to CreateSamplerState. %w", hr)
+	}
+	if hr := d3d.HRESULT(dup.device.CreateBuffer(&d3d11.D3D11_BUFFER_DESC{
+		ByteWidth: 16,
+		Usage:     d3d11.D3D11_USAGE_DEFAULT,
+		BindFlags: d3d11.D3D11_BIND_CONSTANT_BUFFER,
+	}, nil, &tm.modeCB)); hr.Failed() {
+		return fmt.Errorf("failed to CreateBuffer (ToneMapMode cbuffer). %w", hr)
+	}
+	if hr := d3d.HRESULT(dup.device.CreateShaderResourceView(dup.hdrInputTex, nil, &tm.srv)); hr.Failed() {
+		return fmt.Errorf("failed to CreateShaderResourceView. %w", hr)
+	}
+	if hr := d3d.HRESULT(dup.device.CreateRenderTargetView(dup.hdrOutputTex, nil, &tm.rtv)); hr.Failed() {
+		return fmt.Errorf("failed to CreateRenderTargetView. %w", hr)
+	}
+
+	dup.toneMapper = tm
+	return nil
+}
+
+func (tm *toneMapper) Release() {
+	if tm == nil {
+		return
+	}
+	if tm.rtv != nil {
+		tm.rtv.Release()
+	}
+	if tm.srv != nil {
+		tm.srv.Release()
+	}
+	if tm.modeCB != nil {
+		tm.modeCB.Release()
+	}
+	if tm.sampler != nil {
+		tm.sampler.Release()
+	}
+	if tm.ps != nil {
+		tm.ps.Release()
+	}
+	if tm.vs != nil {
+		tm.vs.Release()
+	}
+}
+
+// initializeHDRTextures (re)creates the input/output/staging textures used
+// by the tone-map pass, sized off the just-acquired HDR desktop texture.
+func (dup *OutputDuplicator) initializeHDRTextures(desktop2d *d3d11.ID3D11Texture2D) error {
+	desc := d3d11.D3D11_TEXTURE2D_DESC{}
+	if hr := d3d.HRESULT(desktop2d.GetDesc(&desc)); hr.Failed() {
+		return fmt.Errorf("failed to GetDesc. %w", hr)
+	}
+	dup.size = dxgi.POINT{X: int32(desc.Width), Y: int32(desc.Height)}
+
+	inDesc := desc
+	inDesc.Usage = d3d11.D3D11_USAGE_DEFAULT
+	inDesc.CPUAccessFlags = 0
+	inDesc.BindFlags = d3d11.D3D11_BIND_SHADER_RESOURCE
+	inDesc.MipLevels = 1
+	inDesc.ArraySize = 1
+	inDesc.MiscFlags = 0
+	inDesc.SampleDesc.Count = 1
+	if hr := d3d.HRESULT(dup.device.CreateTexture2D(&inDesc, &dup.hdrInputTex)); hr.Failed() {
+		return fmt.Errorf("failed to create hdrInputTex. %w", hr)
+	}
+
+	outDesc := inDesc
+	outDesc.BindFlags = d3d11.D3D11_BIND_RENDER_TARGET | d3d11.D3D11_BIND_SHADER_RESOURCE
+	if hr := d3d.HRESULT(dup.device.CreateTexture2D(&outDesc, &dup.hdrOutputTex)); hr.Failed() {
+		return fmt.Errorf("failed to create hdrOutputTex. %w", hr)
+	}
+
+	stageDesc := outDesc
+	stageDesc.Usage = d3d11.D3D11_USAGE_STAGING
+	stageDesc.CPUAccessFlags = d3d11.D3D11_CPU_ACCESS_READ
+	stageDesc.BindFlags = 0
+	if hr := d3d.HRESULT(dup.device.CreateTexture2D(&stageDesc, &dup.hdrStageTex)); hr.Failed() {
+		return fmt.Errorf("failed to create hdrStageTex. %w", hr)
+	}
+	if hr := d3d.HRESULT(dup.hdrStageTex.QueryInterface(dxgi.IID_IDXGISurface, &dup.hdrSurface)); hr.Failed() {
+		return fmt.Errorf("failed to QueryInterface(IID_IDXGISurface, ...). %w", hr)
+	}
+
+	return nil
+}
+
+// resolveHDRFrame acquires the next frame, updates hdrInputTex via the usual
+// dirty-rect path, runs the tone-map pass into hdrOutputTex, and downloads
+// the result into hdrStageTex for CPU readback. Shared by GetImageFloat and
+// GetImageNRGBA64.
+func (dup *OutputDuplicator) resolveHDRFrame(timeoutMs uint) error {
+	if dup.pixelFormat != dxgi.DXGI_FORMAT_R16G16B16A16_FLOAT {
+		return fmt.Errorf("GetImageFloat/GetImageNRGBA64 require an OutputDuplicator created with DXGI_FORMAT_R16G16B16A16_FLOAT, got %v", dup.pixelFormat)
+	}
+
+	desktop2d, frameInfo, cleanup, err := dup.acquireFrame(timeoutMs)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if dup.hdrInputTex == nil {
+		if err := dup.initializeHDRTextures(desktop2d); err != nil {
+			return err
+		}
+	}
+	if err := dup.initToneMapper(); err != nil {
+		return err
+	}
+
+	if err := dup.updateTargetFromFrame(dup.hdrInputTex, desktop2d, &frameInfo); err != nil {
+		return err
+	}
+
+	mode := uint32(dup.ToneMap)
+	dup.deviceCtx.UpdateSubresource(dup.toneMapper.modeCB, 0, nil, unsafe.Pointer(&mode), 0, 0)
+	dup.deviceCtx.OMSetRenderTargets([]*d3d11.ID3D11RenderTargetView{dup.toneMapper.rtv}, nil)
+	dup.deviceCtx.PSSetShaderResources(0, []*d3d11.ID3D11ShaderResourceView{dup.toneMapper.srv})
+	dup.deviceCtx.PSSetSamplers(0, []*d3d11.ID3D11SamplerState{dup.toneMapper.sampler})
+	dup.deviceCtx.PSSetConstantBuffers(0, []*d3d11.ID3D11Buffer{dup.toneMapper.modeCB})
+	dup.deviceCtx.VSSetShader(dup.toneMapper.vs, nil)
+	dup.deviceCtx.PSSetShader(dup.toneMapper.ps, nil)
+	dup.deviceCtx.IASetPrimitiveTopology(d3d11.D3D11_PRIMITIVE_TOPOLOGY_TRIANGLELIST)
+	dup.deviceCtx.Draw(3, 0)
+
+	dup.deviceCtx.CopyResource2D(dup.hdrStageTex, dup.hdrOutputTex)
+	return nil
+}
+
+// GetImageFloat fills img with the raw (optionally tone-mapped, per ToneMap)
+// DXGI_FORMAT_R16G16B16A16_FLOAT pixels of the next frame. Requires an
+// OutputDuplicator created via NewIDXGIOutputDuplicationHDR/NewIDXGIOutputDuplicationFormat
+// with DXGI_FORMAT_R16G16B16A16_FLOAT.
+func (dup *OutputDuplicator) GetImageFloat(img *ImageRGBAF16, timeoutMs uint) error {
+	if err := dup.resolveHDRFrame(timeoutMs); err != nil {
+		return err
+	}
+	defer dup.hdrSurface.Unmap()
+
+	var mappedRect dxgi.DXGI_MAPPED_RECT
+	if hr := d3d.HRESULT(dup.hdrSurface.Map(&mappedRect, dxgi.DXGI_MAP_READ)); hr.Failed() {
+		return fmt.Errorf("failed to hdrSurface.Map(...). %w", hr)
+	}
+
+	contentWidth := int(dup.size.X) * 8
+	dataWidth := int(mappedRect.Pitch)
+	dataSize := dataWidth * int(dup.size.Y)
+	data := unsafe.Slice((*byte)(mappedRect.PBits), dataSize)
+
+	var imgStart, dataStart, dataEnd int
+	for i := 0; i < int(dup.size.Y); i++ {
+		dataEnd = dataStart + contentWidth
+		copy(img.Pix[imgStart:], data[dataStart:dataEnd])
+		imgStart += contentWidth
+		dataStart += dataWidth
+	}
+	return nil
+}
+
+// GetImageNRGBA64 fills img with the tone-mapped frame quantized to 16 bits
+// per channel. Requires the same HDR-format OutputDuplicator as GetImageFloat.
+func (dup *OutputDuplicator) GetImageNRGBA64(img *image.NRGBA64, timeoutMs uint) error {
+	if err := dup.resolveHDRFrame(timeoutMs); err != nil {
+		return err
+	}
+	defer dup.hdrSurface.Unmap()
+
+	var mappedRect dxgi.DXGI_MAPPED_RECT
+	if hr := d3d.HRESULT(dup.hdrSurface.Map(&mappedRect, dxgi.DXGI_MAP_READ)); hr.Failed() {
+		return fmt.Errorf("failed to hdrSurface.Map(...). %w", hr)
+	}
+
+	dataWidth := int(mappedRect.Pitch)
+	dataSize := dataWidth * int(dup.size.Y)
+	data := unsafe.Slice((*byte)(mappedRect.PBits), dataSize)
+
+	for y := 0; y < int(dup.size.Y); y++ {
+		row := data[y*dataWidth:]
+		for x := 0; x < int(dup.size.X); x++ {
+			px := row[x*8 : x*8+8]
+			r := halfToUnorm16(uint16(px[0]) | uint16(px[1])<<8)
+			g := halfToUnorm16(uint16(px[2]) | uint16(px[3])<<8)
+			b := halfToUnorm16(uint16(px[4]) | uint16(px[5])<<8)
+			a := halfToUnorm16(uint16(px[6]) | uint16(px[7])<<8)
+			img.SetNRGBA64(x, y, color.NRGBA64{R: r, G: g, B: b, A: a})
+		}
+	}
+	return nil
+}
+
+// halfToUnorm16 decodes an IEEE 754 binary16 value (already tone-mapped into
+// [0, 1] by the PS) into a 16-bit unorm channel, clamping out-of-range bits
+// rather than wrapping them.
+func halfToUnorm16(h uint16) uint16 {
+	sign := h & 0x8000
+	exp := (h >> 10) & 0x1F
+	mant := h & 0x3FF
+
+	var f float64
+	switch {
+	case exp == 0:
+		f = float64(mant) / 1024.0 * math.Pow(2, -14)
+	case exp == 0x1F:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = (1.0 + float64(mant)/1024.0) * math.Pow(2, float64(exp)-15)
+	}
+	if sign != 0 {
+		f = -f
+	}
+
+	if math.IsNaN(f) || f < 0 {
+		return 0
+	}
+	if f > 1 {
+		f = 1
+	}
+	return uint16(f*65535.0 + 0.5)
+}
@@ -0,0 +1,26 @@
+package outputduplication
+
+import "testing"
+
+func TestHalfToUnorm16(t *testing.T) {
+	cases := []struct {
+		name string
+		h    uint16
+		want uint16
+	}{
+		{"zero", 0x0000, 0},
+		{"one", 0x3C00, 65535},
+		{"smallest subnormal rounds to zero", 0x0001, 0},
+		{"positive infinity clamps to max", 0x7C00, 65535},
+		{"negative infinity clamps to zero", 0xFC00, 0},
+		{"NaN clamps to zero", 0x7E00, 0},
+		{"negative one clamps to zero", 0xBC00, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := halfToUnorm16(c.h); got != c.want {
+				t.Errorf("halfToUnorm16(0x%04X) = %d, want %d", c.h, got, c.want)
+			}
+		})
+	}
+}
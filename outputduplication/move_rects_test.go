@@ -0,0 +1,73 @@
+package outputduplication
+
+import (
+	"testing"
+
+	"github.com/kirides/go-d3d/dxgi"
+)
+
+func TestPlanMoveRectsBoxArithmetic(t *testing.T) {
+	movedRects := []dxgi.DXGI_OUTDUPL_MOVE_RECT{
+		{
+			SourcePoint:     dxgi.POINT{X: 10, Y: 20},
+			DestinationRect: dxgi.RECT{Left: 100, Top: 200, Right: 150, Bottom: 240},
+		},
+		{
+			// Overlapping source/destination: this is exactly the case
+			// scratchTex exists to handle correctly.
+			SourcePoint:     dxgi.POINT{X: 0, Y: 0},
+			DestinationRect: dxgi.RECT{Left: 5, Top: 5, Right: 25, Bottom: 25},
+		},
+	}
+
+	ops := planMoveRects(movedRects)
+	if len(ops) != len(movedRects) {
+		t.Fatalf("got %d ops, want %d", len(ops), len(movedRects))
+	}
+
+	op0 := ops[0]
+	if op0.SrcBox.Left != 10 || op0.SrcBox.Top != 20 || op0.SrcBox.Right != 60 || op0.SrcBox.Bottom != 60 {
+		t.Errorf("op0.SrcBox = %+v, want Left=10 Top=20 Right=60 Bottom=60", op0.SrcBox)
+	}
+	if op0.SrcBox.Front != 0 || op0.SrcBox.Back != 1 {
+		t.Errorf("op0.SrcBox depth = Front=%d Back=%d, want 0/1", op0.SrcBox.Front, op0.SrcBox.Back)
+	}
+	if op0.ScratchBox.Left != 0 || op0.ScratchBox.Top != 0 || op0.ScratchBox.Right != 50 || op0.ScratchBox.Bottom != 40 {
+		t.Errorf("op0.ScratchBox = %+v, want origin-relative 50x40", op0.ScratchBox)
+	}
+	if op0.DstX != 100 || op0.DstY != 200 {
+		t.Errorf("op0 dst = (%d,%d), want (100,200)", op0.DstX, op0.DstY)
+	}
+	if op0.Changed != movedRects[0].DestinationRect {
+		t.Errorf("op0.Changed = %+v, want %+v", op0.Changed, movedRects[0].DestinationRect)
+	}
+
+	op1 := ops[1]
+	wantW, wantH := uint32(20), uint32(20)
+	if op1.SrcBox.Right-op1.SrcBox.Left != wantW || op1.SrcBox.Bottom-op1.SrcBox.Top != wantH {
+		t.Errorf("op1 src size = %dx%d, want %dx%d", op1.SrcBox.Right-op1.SrcBox.Left, op1.SrcBox.Bottom-op1.SrcBox.Top, wantW, wantH)
+	}
+	if op1.ScratchBox.Right != wantW || op1.ScratchBox.Bottom != wantH {
+		t.Errorf("op1 scratch size = %dx%d, want %dx%d", op1.ScratchBox.Right, op1.ScratchBox.Bottom, wantW, wantH)
+	}
+}
+
+func TestPlanMoveRectsEmpty(t *testing.T) {
+	if ops := planMoveRects(nil); len(ops) != 0 {
+		t.Fatalf("got %d ops for nil input, want 0", len(ops))
+	}
+}
+
+func TestPlanMoveRectsPreservesOrder(t *testing.T) {
+	movedRects := []dxgi.DXGI_OUTDUPL_MOVE_RECT{
+		{DestinationRect: dxgi.RECT{Left: 1, Top: 1, Right: 2, Bottom: 2}},
+		{DestinationRect: dxgi.RECT{Left: 3, Top: 3, Right: 4, Bottom: 4}},
+		{DestinationRect: dxgi.RECT{Left: 5, Top: 5, Right: 6, Bottom: 6}},
+	}
+	ops := planMoveRects(movedRects)
+	for i, op := range ops {
+		if op.Changed != movedRects[i].DestinationRect {
+			t.Errorf("ops[%d].Changed = %+v, want %+v", i, op.Changed, movedRects[i].DestinationRect)
+		}
+	}
+}
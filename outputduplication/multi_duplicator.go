@@ -0,0 +1,349 @@
+package outputduplication
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"sync"
+	"time"
+
+	"github.com/kirides/go-d3d"
+	"github.com/kirides/go-d3d/d3d11"
+	"github.com/kirides/go-d3d/dxgi"
+)
+
+// DeviceForAdapter creates the D3D11 device/context MultiDuplicator should
+// use to duplicate a single output of the given adapter index. It is called
+// once per output (not once per adapter), since an ID3D11DeviceContext is an
+// immediate context and cannot be shared across the goroutines that drive
+// independent OutputDuplicators without external serialization. Implementations
+// typically call d3d11.D3D11CreateDevice against the matching IDXGIAdapter1.
+type DeviceForAdapter func(adapterIndex uint) (*d3d11.ID3D11Device, *d3d11.ID3D11DeviceContext, error)
+
+// reopenBackoff is how long run() waits between reopen attempts while an
+// output stays unavailable (unplugged, disabled, ...), so a persistently
+// failing output doesn't spin the goroutine at full CPU.
+const reopenBackoff = 500 * time.Millisecond
+
+// Frame announces that outputIndex has composited fresh pixels into the
+// image passed to GetVirtualDesktop. It carries no pixel data itself so
+// consuming it never blocks the producing worker; callers read the
+// composited image under GetVirtualDesktop afterwards.
+type Frame struct {
+	OutputIndex int
+	Bounds      image.Rectangle
+}
+
+// MultiDuplicator aggregates every IDXGIOutput on every adapter into a
+// single virtual-desktop image, one goroutine per output, mirroring the
+// ThreadManager/OutputManager/DisplayManager split of Microsoft's WinDD
+// sample. Use NewMultiDuplicator to build one and Frames() to consume
+// updates; call Release when done.
+type MultiDuplicator struct {
+	workers []*duplicatorWorker
+
+	mu       sync.Mutex
+	bounds   image.Rectangle
+	composed *image.RGBA
+
+	frames chan Frame
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+type duplicatorWorker struct {
+	index        int
+	adapterIndex uint
+	outputIndex  uint
+	device       *d3d11.ID3D11Device
+	deviceCtx    *d3d11.ID3D11DeviceContext
+	deviceFn     DeviceForAdapter
+
+	dup      *OutputDuplicator
+	bounds   image.Rectangle
+	rotation dxgi.DXGI_MODE_ROTATION
+}
+
+// NewMultiDuplicator enumerates every output of every adapter, calling
+// deviceFn once per output (each output gets its own D3D11 device/immediate
+// context, since contexts can't be shared across the goroutines that drive
+// them), builds one OutputDuplicator per output, and starts one capture
+// goroutine per output. The outputs are composited into a single
+// virtual-desktop image sized to the union of every
+// DXGI_OUTPUT_DESC.DesktopCoordinates.
+func NewMultiDuplicator(deviceFn DeviceForAdapter) (*MultiDuplicator, error) {
+	factory, err := dxgi.CreateDXGIFactory1()
+	if err != nil {
+		return nil, fmt.Errorf("failed to CreateDXGIFactory1. %w", err)
+	}
+	defer factory.Release()
+
+	md := &MultiDuplicator{
+		frames: make(chan Frame, 64),
+		stop:   make(chan struct{}),
+	}
+
+	for adapterIndex := uint32(0); ; adapterIndex++ {
+		var adapter *dxgi.IDXGIAdapter1
+		hr := int32(factory.EnumAdapters1(adapterIndex, &adapter))
+		if hr := d3d.HRESULT(hr); hr.Failed() {
+			if hr == d3d.DXGI_ERROR_NOT_FOUND {
+				break
+			}
+			return nil, fmt.Errorf("failed to EnumAdapters1(%d, ...). %w", adapterIndex, hr)
+		}
+
+		for outputIndex := uint32(0); ; outputIndex++ {
+			var output *dxgi.IDXGIOutput
+			hr := int32(adapter.EnumOutputs(outputIndex, &output))
+			if hr := d3d.HRESULT(hr); hr.Failed() {
+				if hr == d3d.DXGI_ERROR_NOT_FOUND {
+					break
+				}
+				adapter.Release()
+				md.Release()
+				return nil, fmt.Errorf("failed to EnumOutputs(%d, ...). %w", outputIndex, hr)
+			}
+			output.Release()
+
+			device, deviceCtx, err := deviceFn(uint(adapterIndex))
+			if err != nil {
+				adapter.Release()
+				md.Release()
+				return nil, fmt.Errorf("deviceFn(%d) failed. %w", adapterIndex, err)
+			}
+
+			w := &duplicatorWorker{
+				index:        len(md.workers),
+				adapterIndex: uint(adapterIndex),
+				outputIndex:  uint(outputIndex),
+				device:       device,
+				deviceCtx:    deviceCtx,
+				deviceFn:     deviceFn,
+			}
+			if err := w.open(); err != nil {
+				adapter.Release()
+				md.Release()
+				return nil, fmt.Errorf("failed to open duplicator for adapter %d output %d. %w", adapterIndex, outputIndex, err)
+			}
+			md.workers = append(md.workers, w)
+			md.bounds = md.bounds.Union(w.bounds)
+		}
+		adapter.Release()
+	}
+
+	if len(md.workers) == 0 {
+		return nil, errors.New("outputduplication: no outputs found")
+	}
+
+	md.composed = image.NewRGBA(md.bounds)
+
+	for _, w := range md.workers {
+		md.wg.Add(1)
+		go md.run(w)
+	}
+
+	return md, nil
+}
+
+func (w *duplicatorWorker) open() error {
+	dup, err := NewIDXGIOutputDuplication(w.device, w.deviceCtx, w.outputIndex)
+	if err != nil {
+		return err
+	}
+	bounds, err := dup.GetBounds()
+	if err != nil {
+		dup.Release()
+		return err
+	}
+
+	desc := dxgi.DXGI_OUTPUT_DESC{}
+	if hr := d3d.HRESULT(dup.dxgiOutput.GetDesc(&desc)); hr.Failed() {
+		dup.Release()
+		return fmt.Errorf("failed to GetDesc. %w", hr)
+	}
+
+	w.dup = dup
+	w.bounds = bounds
+	w.rotation = desc.Rotation
+	return nil
+}
+
+// reopen rebuilds the duplicator after DXGI_ERROR_ACCESS_LOST (display mode
+// change, GPU reset, session switch, ...), matching ddagrab/the reference
+// sample's "just recreate it" recovery strategy.
+func (w *duplicatorWorker) reopen() error {
+	if w.dup != nil {
+		w.dup.Release()
+		w.dup = nil
+	}
+	return w.open()
+}
+
+// Frames returns the channel on which a Frame is posted each time a worker
+// composites a fresh image into the virtual desktop. Reading it is
+// optional: GetVirtualDesktop can always be polled directly.
+func (md *MultiDuplicator) Frames() <-chan Frame {
+	return md.frames
+}
+
+// Bounds returns the virtual-desktop rectangle GetVirtualDesktop composites into.
+func (md *MultiDuplicator) Bounds() image.Rectangle {
+	return md.bounds
+}
+
+// GetVirtualDesktop copies the latest composited frame into img, which must
+// be at least Bounds() in size. img is addressed in its own zero-based
+// coordinate space (as image.NewRGBA(image.Rect(0, 0, w, h)) would produce),
+// not in Bounds()'s raw desktop coordinates, which are frequently
+// negative-origin when an output is placed left of or above the primary.
+// It never blocks on capture; it simply returns whatever the worker
+// goroutines have composited so far.
+func (md *MultiDuplicator) GetVirtualDesktop(img *image.RGBA) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	dst := image.Rect(0, 0, md.bounds.Dx(), md.bounds.Dy()).Add(img.Bounds().Min)
+	draw.Draw(img, dst, md.composed, md.composed.Bounds().Min, draw.Src)
+}
+
+func (md *MultiDuplicator) run(w *duplicatorWorker) {
+	defer md.wg.Done()
+
+	// Captured pixels come in the output's unrotated orientation (see
+	// rotateForOutput), so a 90/270-rotated output's frame is w.bounds with
+	// width and height swapped.
+	localRect := func() image.Rectangle {
+		dx, dy := w.bounds.Dx(), w.bounds.Dy()
+		if w.rotation == dxgi.DXGI_MODE_ROTATION_ROTATE90 || w.rotation == dxgi.DXGI_MODE_ROTATION_ROTATE270 {
+			dx, dy = dy, dx
+		}
+		return image.Rect(0, 0, dx, dy)
+	}
+	local := image.NewRGBA(localRect())
+	resizeLocal := func() {
+		if r := localRect(); local.Bounds() != r {
+			local = image.NewRGBA(r)
+		}
+	}
+	for {
+		select {
+		case <-md.stop:
+			return
+		default:
+		}
+
+		if w.dup == nil {
+			// Adapter/output likely unplugged and the last reopen failed; back
+			// off instead of busy-looping until it comes back.
+			select {
+			case <-md.stop:
+				return
+			case <-time.After(reopenBackoff):
+			}
+			if rerr := w.reopen(); rerr != nil {
+				continue
+			}
+			// reopen() re-fetched DXGI_OUTPUT_DESC, which may have a new mode
+			// (the access-lost/unplug scenario reopen exists to recover from).
+			resizeLocal()
+		}
+
+		err := w.dup.GetImage(local, 1000)
+		if err != nil {
+			if errors.Is(err, ErrNoImageYet) {
+				continue
+			}
+			if errors.Is(err, d3d.DXGI_ERROR_ACCESS_LOST) {
+				if rerr := w.reopen(); rerr != nil {
+					continue
+				}
+				resizeLocal()
+				continue
+			}
+			continue
+		}
+
+		rotated := rotateForOutput(local, w.rotation)
+
+		md.mu.Lock()
+		draw.Draw(md.composed, w.bounds, rotated, rotated.Bounds().Min, draw.Src)
+		md.mu.Unlock()
+
+		select {
+		case md.frames <- Frame{OutputIndex: w.index, Bounds: w.bounds}:
+		default:
+			// Drop the notification rather than block capture; GetVirtualDesktop
+			// always reflects the latest composited pixels regardless.
+		}
+	}
+}
+
+// rotateForOutput rotates img so it matches the output's unrotated desktop
+// coordinate space. DXGI reports captured pixels pre-rotated to match
+// DXGI_MODE_ROTATION, i.e. a portrait-rotated monitor still hands back
+// landscape pixels that need rotating to line up in the virtual desktop.
+func rotateForOutput(img *image.RGBA, rotation dxgi.DXGI_MODE_ROTATION) *image.RGBA {
+	switch rotation {
+	case dxgi.DXGI_MODE_ROTATION_ROTATE90:
+		return rotateRGBA90(img)
+	case dxgi.DXGI_MODE_ROTATION_ROTATE180:
+		return rotateRGBA180(img)
+	case dxgi.DXGI_MODE_ROTATION_ROTATE270:
+		return rotateRGBA270(img)
+	default:
+		return img
+	}
+}
+
+func rotateRGBA90(src *image.RGBA) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dst.SetRGBA(b.Dy()-1-y, x, src.RGBAAt(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+func rotateRGBA180(src *image.RGBA) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dst.SetRGBA(b.Dx()-1-x, b.Dy()-1-y, src.RGBAAt(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+func rotateRGBA270(src *image.RGBA) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dst.SetRGBA(y, b.Dx()-1-x, src.RGBAAt(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// Release stops every worker goroutine and releases every underlying
+// OutputDuplicator. It does not release the devices passed in via deviceFn;
+// callers own those.
+func (md *MultiDuplicator) Release() {
+	select {
+	case <-md.stop:
+		// already closed
+	default:
+		close(md.stop)
+	}
+	md.wg.Wait()
+	for _, w := range md.workers {
+		if w.dup != nil {
+			w.dup.Release()
+			w.dup = nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package outputduplication
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func makeTestRGBA(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 1, A: 255})
+		}
+	}
+	return img
+}
+
+func TestRotateRGBARoundTrip(t *testing.T) {
+	cases := []struct {
+		name    string
+		rotate  func(*image.RGBA) *image.RGBA
+		inverse func(*image.RGBA) *image.RGBA
+	}{
+		{"90", rotateRGBA90, rotateRGBA270},
+		{"180", rotateRGBA180, rotateRGBA180},
+		{"270", rotateRGBA270, rotateRGBA90},
+	}
+	src := makeTestRGBA(3, 5)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.inverse(c.rotate(src))
+			b := src.Bounds()
+			for y := 0; y < b.Dy(); y++ {
+				for x := 0; x < b.Dx(); x++ {
+					want := src.RGBAAt(b.Min.X+x, b.Min.Y+y)
+					have := got.RGBAAt(x, y)
+					if have != want {
+						t.Fatalf("pixel (%d,%d): got %+v, want %+v", x, y, have, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRotateRGBADimensions(t *testing.T) {
+	src := makeTestRGBA(4, 7)
+	if b := rotateRGBA90(src).Bounds(); b.Dx() != 7 || b.Dy() != 4 {
+		t.Errorf("rotateRGBA90: got %dx%d, want 7x4", b.Dx(), b.Dy())
+	}
+	if b := rotateRGBA180(src).Bounds(); b.Dx() != 4 || b.Dy() != 7 {
+		t.Errorf("rotateRGBA180: got %dx%d, want 4x7", b.Dx(), b.Dy())
+	}
+	if b := rotateRGBA270(src).Bounds(); b.Dx() != 7 || b.Dy() != 4 {
+		t.Errorf("rotateRGBA270: got %dx%d, want 7x4", b.Dx(), b.Dy())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"time"
 
 	"unsafe"
 
@@ -21,6 +22,10 @@ type PointerInfo struct {
 	shapeInBuffer  []byte
 	shapeOutBuffer *image.RGBA
 	visible        bool
+	// isMonochrome records whether the current shape came from
+	// DXGI_OUTDUPL_POINTER_SHAPE_TYPE_MONOCHROME, so drawPointerGPU knows to
+	// use the XOR-against-background pixel shader instead of straight alpha.
+	isMonochrome bool
 }
 
 type OutputDuplicator struct {
@@ -39,12 +44,57 @@ type OutputDuplicator struct {
 	DrawPointer bool
 	// Update pointer information when it changes, used with DrawCursor(image)
 	UpdatePointerInfo bool
+	// DrawPointerGPU composites the pointer onto renderTex directly on the
+	// GPU during SnapshotTexture via a single textured-quad draw call,
+	// instead of the CPU img.At/img.Set loop DrawPointer uses. Requires
+	// SnapshotTexture (i.e. renderTex) to be in use; ignored by Snapshot/GetImage.
+	DrawPointerGPU bool
+
+	cursorGPU *cursorGPUPipeline
+
+	// Capture configures Snapshot's frame-pacing/timeout behavior; see CaptureConfig.
+	Capture      CaptureConfig
+	stats        CaptureStats
+	lastFrameAt  time.Time
+	haveAnyFrame bool
 
 	// TODO: handle DPI? Do we need it?
 	dirtyRects    []dxgi.RECT
 	movedRects    []dxgi.DXGI_OUTDUPL_MOVE_RECT
 	acquiredFrame bool
 	needsSwizzle  bool // in case we use DuplicateOutput1, swizzle is not neccessery
+
+	// scratchTex is a same-sized DEFAULT-usage texture used as an
+	// intermediate hop when applying move rects, so a move whose source and
+	// destination overlap doesn't corrupt itself mid-copy.
+	scratchTex *d3d11.ID3D11Texture2D
+	// changedRects is the union of this frame's applied move-destination and
+	// dirty rects, valid until the next updateTargetFromFrame call or repeated
+	// Snapshot (which empties it). See ChangedRects.
+	changedRects []dxgi.RECT
+
+	// renderTex is a GPU-local (D3D11_USAGE_DEFAULT) copy of the desktop, kept
+	// up to date the same way stagedTex is, but never mapped for CPU access.
+	// It backs SnapshotTexture so callers can hand the frame straight to an
+	// encoder or another D3D11 consumer without a readback.
+	renderTex       *d3d11.ID3D11Texture2D
+	renderTexShared *dxgi.IDXGIResource1
+	textureAcquired bool
+
+	// pixelFormat is the DXGI_FORMAT negotiated with DuplicateOutput1/DuplicateOutput.
+	// Only DXGI_FORMAT_R8G8B8A8_UNORM (GetImage) and DXGI_FORMAT_R16G16B16A16_FLOAT
+	// (GetImageFloat/GetImageNRGBA64) are supported.
+	pixelFormat dxgi.DXGI_FORMAT
+
+	// ToneMap selects how HDR (RGBA16F) frames are mapped before GetImageFloat /
+	// GetImageNRGBA64 hand them back. Ignored for DXGI_FORMAT_R8G8B8A8_UNORM capture.
+	ToneMap ToneMapMode
+
+	hdrInputTex  *d3d11.ID3D11Texture2D // DEFAULT, SHADER_RESOURCE: raw HDR desktop, updated via dirty rects
+	hdrOutputTex *d3d11.ID3D11Texture2D // DEFAULT, RENDER_TARGET: tone-mapped result
+	hdrStageTex  *d3d11.ID3D11Texture2D // STAGING: CPU-readable copy of hdrOutputTex
+	hdrSurface   *dxgi.IDXGISurface
+	toneMapper   *toneMapper
 }
 
 func (dup *OutputDuplicator) initializeStage(texture *d3d11.ID3D11Texture2D) int32 {
@@ -83,6 +133,7 @@ func (dup *OutputDuplicator) initializeStage(texture *d3d11.ID3D11Texture2D) int
 
 func (dup *OutputDuplicator) Release() {
 	dup.ReleaseFrame()
+	dup.ReleaseTexture()
 	if dup.stagedTex != nil {
 		dup.stagedTex.Release()
 		dup.stagedTex = nil
@@ -91,6 +142,42 @@ func (dup *OutputDuplicator) Release() {
 		dup.surface.Release()
 		dup.surface = nil
 	}
+	if dup.renderTexShared != nil {
+		dup.renderTexShared.Release()
+		dup.renderTexShared = nil
+	}
+	if dup.renderTex != nil {
+		dup.renderTex.Release()
+		dup.renderTex = nil
+	}
+	if dup.hdrSurface != nil {
+		dup.hdrSurface.Release()
+		dup.hdrSurface = nil
+	}
+	if dup.hdrStageTex != nil {
+		dup.hdrStageTex.Release()
+		dup.hdrStageTex = nil
+	}
+	if dup.hdrOutputTex != nil {
+		dup.hdrOutputTex.Release()
+		dup.hdrOutputTex = nil
+	}
+	if dup.hdrInputTex != nil {
+		dup.hdrInputTex.Release()
+		dup.hdrInputTex = nil
+	}
+	if dup.toneMapper != nil {
+		dup.toneMapper.Release()
+		dup.toneMapper = nil
+	}
+	if dup.scratchTex != nil {
+		dup.scratchTex.Release()
+		dup.scratchTex = nil
+	}
+	if dup.cursorGPU != nil {
+		dup.cursorGPU.Release()
+		dup.cursorGPU = nil
+	}
 	if dup.outputDuplication != nil {
 		dup.outputDuplication.Release()
 		dup.outputDuplication = nil
@@ -101,6 +188,41 @@ func (dup *OutputDuplicator) Release() {
 	}
 }
 
+// initializeRenderTex creates a GPU-local, non-staging copy of the desktop
+// texture that can be handed out directly via SnapshotTexture. It is bound
+// as a shader resource so consumers (compositors, encoders) can sample it,
+// and as a render target so drawPointerGPU can draw the cursor onto it. It
+// also records the desktop size in dup.size, and carries D3D11_RESOURCE_MISC_SHARED_NTHANDLE so it can be opened from
+// another device/process via IDXGIResource1.CreateSharedHandle.
+func (dup *OutputDuplicator) initializeRenderTex(texture *d3d11.ID3D11Texture2D) int32 {
+	desc := d3d11.D3D11_TEXTURE2D_DESC{}
+	hr := texture.GetDesc(&desc)
+	if d3d.HRESULT(hr).Failed() {
+		return hr
+	}
+	dup.size = dxgi.POINT{X: int32(desc.Width), Y: int32(desc.Height)}
+
+	desc.Usage = d3d11.D3D11_USAGE_DEFAULT
+	desc.CPUAccessFlags = 0
+	desc.BindFlags = d3d11.D3D11_BIND_SHADER_RESOURCE | d3d11.D3D11_BIND_RENDER_TARGET
+	desc.MipLevels = 1
+	desc.ArraySize = 1
+	desc.MiscFlags = d3d11.D3D11_RESOURCE_MISC_SHARED_NTHANDLE | d3d11.D3D11_RESOURCE_MISC_SHARED_KEYEDMUTEX
+	desc.SampleDesc.Count = 1
+
+	hr = dup.device.CreateTexture2D(&desc, &dup.renderTex)
+	if d3d.HRESULT(hr).Failed() {
+		return hr
+	}
+
+	hr = dup.renderTex.QueryInterface(dxgi.IID_IDXGIResource1, &dup.renderTexShared)
+	if d3d.HRESULT(hr).Failed() {
+		return hr
+	}
+
+	return 0
+}
+
 var ErrNoImageYet = errors.New("no image yet")
 
 type unmapFn func() int32
@@ -112,24 +234,12 @@ func (dup *OutputDuplicator) ReleaseFrame() {
 	}
 }
 
-// returns DXGI_FORMAT_B8G8R8A8_UNORM data
-func (dup *OutputDuplicator) Snapshot(timeoutMs uint) (unmapFn, *dxgi.DXGI_MAPPED_RECT, *dxgi.POINT, error) {
-	var hr int32
-	desc := dxgi.DXGI_OUTDUPL_DESC{}
-	hr = dup.outputDuplication.GetDesc(&desc)
-	if hr := d3d.HRESULT(hr); hr.Failed() {
-		return nil, nil, nil, fmt.Errorf("failed to get the description. %w", hr)
-	}
-
-	if desc.DesktopImageInSystemMemory != 0 {
-		// TODO: Figure out WHEN exactly this can occur, and if we can make use of it
-		dup.size = dxgi.POINT{X: int32(desc.ModeDesc.Width), Y: int32(desc.ModeDesc.Height)}
-		hr = dup.outputDuplication.MapDesktopSurface(&dup.mappedRect)
-		if hr := d3d.HRESULT(hr); !hr.Failed() {
-			return dup.outputDuplication.UnMapDesktopSurface, &dup.mappedRect, &dup.size, nil
-		}
-	}
-
+// acquireFrame calls AcquireNextFrame and returns the desktop as a
+// *d3d11.ID3D11Texture2D, along with a cleanup func the caller must defer to
+// release both the desktop resource and the duplication frame. It does not
+// handle the DesktopImageInSystemMemory case, which callers must check for
+// themselves beforehand.
+func (dup *OutputDuplicator) acquireFrame(timeoutMs uint) (*d3d11.ID3D11Texture2D, dxgi.DXGI_OUTDUPL_FRAME_INFO, func(), error) {
 	var desktop *dxgi.IDXGIResource
 	var frameInfo dxgi.DXGI_OUTDUPL_FRAME_INFO
 
@@ -138,36 +248,129 @@ func (dup *OutputDuplicator) Snapshot(timeoutMs uint) (unmapFn, *dxgi.DXGI_MAPPE
 	dup.acquiredFrame = true
 	if hr := d3d.HRESULT(hrF); hr.Failed() {
 		if hr == d3d.DXGI_ERROR_WAIT_TIMEOUT {
-			return nil, nil, nil, ErrNoImageYet
+			return nil, frameInfo, nil, ErrNoImageYet
 		}
-		return nil, nil, nil, fmt.Errorf("failed to AcquireNextFrame. %w", d3d.HRESULT(hrF))
+		return nil, frameInfo, nil, fmt.Errorf("failed to AcquireNextFrame. %w", d3d.HRESULT(hrF))
 	}
 
-	defer dup.ReleaseFrame()
-	defer desktop.Release()
-
 	if dup.UpdatePointerInfo {
 		if err := dup.updatePointer(&frameInfo); err != nil {
-			return nil, nil, nil, err
+			dup.ReleaseFrame()
+			desktop.Release()
+			return nil, frameInfo, nil, err
 		}
 	}
 
 	if frameInfo.AccumulatedFrames == 0 {
-		return nil, nil, nil, ErrNoImageYet
+		dup.ReleaseFrame()
+		desktop.Release()
+		return nil, frameInfo, nil, ErrNoImageYet
 	}
+
 	var desktop2d *d3d11.ID3D11Texture2D
-	hr = desktop.QueryInterface(d3d11.IID_ID3D11Texture2D, &desktop2d)
+	hr := desktop.QueryInterface(d3d11.IID_ID3D11Texture2D, &desktop2d)
 	if hr := d3d.HRESULT(hr); hr.Failed() {
-		return nil, nil, nil, fmt.Errorf("failed to QueryInterface(iid_ID3D11Texture2D, ...). %w", hr)
+		dup.ReleaseFrame()
+		desktop.Release()
+		return nil, frameInfo, nil, fmt.Errorf("failed to QueryInterface(iid_ID3D11Texture2D, ...). %w", hr)
 	}
-	defer desktop2d.Release()
 
-	if dup.stagedTex == nil {
-		hr = dup.initializeStage(desktop2d)
-		if hr := d3d.HRESULT(hr); hr.Failed() {
-			return nil, nil, nil, fmt.Errorf("failed to InitializeStage. %w", hr)
+	cleanup := func() {
+		desktop2d.Release()
+		desktop.Release()
+		dup.ReleaseFrame()
+	}
+	return desktop2d, frameInfo, cleanup, nil
+}
+
+// moveRectOp is the box/offset arithmetic applyMoveRects needs to replay a
+// single DXGI_OUTDUPL_MOVE_RECT as two CopySubresourceRegion2D calls: first
+// SrcBox (a region of target) into scratchTex at the origin, then
+// ScratchBox (that same region, now at the scratchTex origin) back into
+// target at DstX/DstY. Routing through scratchTex (rather than copying
+// target -> target directly) avoids corruption when a move's source and
+// destination rects overlap, matching the reference DuplicationManager
+// sample's two-pass approach.
+type moveRectOp struct {
+	SrcBox     d3d11.D3D11_BOX
+	ScratchBox d3d11.D3D11_BOX
+	DstX, DstY uint32
+	Changed    dxgi.RECT
+}
+
+// planMoveRects converts DXGI_OUTDUPL_MOVE_RECT entries into the moveRectOp
+// values applyMoveRects executes, pulled out as pure rect arithmetic so it's
+// testable without a D3D11 device; see move_rects_test.go.
+func planMoveRects(movedRects []dxgi.DXGI_OUTDUPL_MOVE_RECT) []moveRectOp {
+	ops := make([]moveRectOp, len(movedRects))
+	for i := range movedRects {
+		mr := &movedRects[i]
+		width := uint32(mr.DestinationRect.Right - mr.DestinationRect.Left)
+		height := uint32(mr.DestinationRect.Bottom - mr.DestinationRect.Top)
+
+		ops[i] = moveRectOp{
+			SrcBox: d3d11.D3D11_BOX{
+				Left:   uint32(mr.SourcePoint.X),
+				Top:    uint32(mr.SourcePoint.Y),
+				Front:  0,
+				Right:  uint32(mr.SourcePoint.X) + width,
+				Bottom: uint32(mr.SourcePoint.Y) + height,
+				Back:   1,
+			},
+			ScratchBox: d3d11.D3D11_BOX{
+				Left: 0, Top: 0, Front: 0,
+				Right: width, Bottom: height, Back: 1,
+			},
+			DstX:    uint32(mr.DestinationRect.Left),
+			DstY:    uint32(mr.DestinationRect.Top),
+			Changed: mr.DestinationRect,
 		}
 	}
+	return ops
+}
+
+// applyMoveRects replays dup.movedRects against target using planMoveRects'
+// box arithmetic; see moveRectOp for why the copy goes through scratchTex.
+func (dup *OutputDuplicator) applyMoveRects(target *d3d11.ID3D11Texture2D) error {
+	if dup.scratchTex == nil {
+		desc := d3d11.D3D11_TEXTURE2D_DESC{}
+		if hr := d3d.HRESULT(target.GetDesc(&desc)); hr.Failed() {
+			return fmt.Errorf("failed to GetDesc for scratchTex. %w", d3d.HRESULT(hr))
+		}
+		desc.Usage = d3d11.D3D11_USAGE_DEFAULT
+		desc.CPUAccessFlags = 0
+		desc.BindFlags = 0
+		desc.MipLevels = 1
+		desc.ArraySize = 1
+		desc.MiscFlags = 0
+		desc.SampleDesc.Count = 1
+		if hr := d3d.HRESULT(dup.device.CreateTexture2D(&desc, &dup.scratchTex)); hr.Failed() {
+			return fmt.Errorf("failed to CreateTexture2D for scratchTex. %w", hr)
+		}
+	}
+
+	for _, op := range planMoveRects(dup.movedRects) {
+		dup.deviceCtx.CopySubresourceRegion2D(dup.scratchTex, 0, 0, 0, 0, target, 0, &op.SrcBox)
+		dup.deviceCtx.CopySubresourceRegion2D(target, 0, op.DstX, op.DstY, 0, dup.scratchTex, 0, &op.ScratchBox)
+		dup.changedRects = append(dup.changedRects, op.Changed)
+	}
+	return nil
+}
+
+// ChangedRects returns the union of move-destination and dirty rects applied
+// by the most recent Snapshot/SnapshotTexture call, in target-texture
+// coordinates. Callers doing incremental encoding can use this instead of
+// re-diffing the whole frame. The returned slice is reused internally and is
+// only valid until the next Snapshot/SnapshotTexture call.
+func (dup *OutputDuplicator) ChangedRects() []dxgi.RECT {
+	return dup.changedRects
+}
+
+// updateTargetFromFrame applies the moved/dirty rect metadata of frameInfo,
+// copying the changed regions of desktop2d into target. When there is no
+// metadata at all it falls back to copying the whole surface.
+func (dup *OutputDuplicator) updateTargetFromFrame(target *d3d11.ID3D11Texture2D, desktop2d *d3d11.ID3D11Texture2D, frameInfo *dxgi.DXGI_OUTDUPL_FRAME_INFO) error {
+	var hr int32
 
 	// NOTE: we could use a single, large []byte buffer and use it as storage for moved rects & dirty rects
 	if frameInfo.TotalMetadataBufferSize > 0 {
@@ -182,7 +385,7 @@ func (dup *OutputDuplicator) Snapshot(timeoutMs uint) (unmapFn, *dxgi.DXGI_MAPPE
 				if hr == d3d.DXGI_ERROR_MORE_DATA {
 					continue
 				}
-				return nil, nil, nil, fmt.Errorf("failed to GetFrameMoveRects. %w", d3d.HRESULT(hr))
+				return fmt.Errorf("failed to GetFrameMoveRects. %w", d3d.HRESULT(hr))
 			}
 			dup.movedRects = dup.movedRects[:moveRectsRequired]
 			break
@@ -198,39 +401,159 @@ func (dup *OutputDuplicator) Snapshot(timeoutMs uint) (unmapFn, *dxgi.DXGI_MAPPE
 				if hr == d3d.DXGI_ERROR_MORE_DATA {
 					continue
 				}
-				return nil, nil, nil, fmt.Errorf("failed to GetFrameDirtyRects. %w", d3d.HRESULT(hr))
+				return fmt.Errorf("failed to GetFrameDirtyRects. %w", d3d.HRESULT(hr))
 			}
 			dup.dirtyRects = dup.dirtyRects[:dirtyRectsRequired]
 			break
 		}
 
+		dup.changedRects = dup.changedRects[:0]
+
 		box := d3d11.D3D11_BOX{
 			Front: 0,
 			Back:  1,
 		}
-		if len(dup.movedRects) == 0 {
-			for i := 0; i < len(dup.dirtyRects); i++ {
-				box.Left = uint32(dup.dirtyRects[i].Left)
-				box.Top = uint32(dup.dirtyRects[i].Top)
-				box.Right = uint32(dup.dirtyRects[i].Right)
-				box.Bottom = uint32(dup.dirtyRects[i].Bottom)
-
-				dup.deviceCtx.CopySubresourceRegion2D(dup.stagedTex, 0, box.Left, box.Top, 0, desktop2d, 0, &box)
+		if len(dup.movedRects) != 0 {
+			if err := dup.applyMoveRects(target); err != nil {
+				return err
 			}
-		} else {
-			// TODO: handle moved rects, then dirty rects
-			// for now, just update the whole image instead
-			dup.deviceCtx.CopyResource2D(dup.stagedTex, desktop2d)
+		}
+		for i := 0; i < len(dup.dirtyRects); i++ {
+			box.Left = uint32(dup.dirtyRects[i].Left)
+			box.Top = uint32(dup.dirtyRects[i].Top)
+			box.Right = uint32(dup.dirtyRects[i].Right)
+			box.Bottom = uint32(dup.dirtyRects[i].Bottom)
+
+			dup.deviceCtx.CopySubresourceRegion2D(target, 0, box.Left, box.Top, 0, desktop2d, 0, &box)
+			dup.changedRects = append(dup.changedRects, dup.dirtyRects[i])
 		}
 	} else {
 		// no frame metadata, copy whole image
-		dup.deviceCtx.CopyResource2D(dup.stagedTex, desktop2d)
+		dup.deviceCtx.CopyResource2D(target, desktop2d)
 		if !dup.needsSwizzle {
 			dup.needsSwizzle = true
 		}
+		dup.changedRects = append(dup.changedRects[:0], dxgi.RECT{Left: 0, Top: 0, Right: dup.size.X, Bottom: dup.size.Y})
 		print("no frame metadata\n")
 	}
 
+	return nil
+}
+
+// acquireFrameFn is the shape of acquireFrame, pulled out as a parameter so
+// acquirePaced's pacing policy can be driven by a fake in tests without a
+// live OutputDuplicator.
+type acquireFrameFn func(timeoutMs uint) (*d3d11.ID3D11Texture2D, dxgi.DXGI_OUTDUPL_FRAME_INFO, func(), error)
+
+// acquireFramePaced wraps acquireFrame with the pacing/repeat policy
+// described by dup.Capture. With the zero-value CaptureConfig it behaves
+// exactly like acquireFrame (one attempt, ErrNoImageYet on timeout). With
+// Capture.TargetInterval set it polls in Capture.PollInterval increments
+// until either a frame arrives, the previous frame is repeated (per
+// Capture.ForceRefreshTimeout), or TargetInterval elapses.
+func (dup *OutputDuplicator) acquireFramePaced(timeoutMs uint) (*d3d11.ID3D11Texture2D, dxgi.DXGI_OUTDUPL_FRAME_INFO, func(), bool, error) {
+	return acquirePaced(dup.Capture, &dup.stats, &dup.haveAnyFrame, &dup.lastFrameAt, time.Now, dup.acquireFrame, timeoutMs)
+}
+
+// acquirePaced implements the pacing state machine acquireFramePaced exposes
+// on OutputDuplicator, taking the clock and the underlying acquire call as
+// parameters so it can be exercised without any D3D device; see
+// acquire_paced_test.go.
+func acquirePaced(cfg CaptureConfig, stats *CaptureStats, haveAnyFrame *bool, lastFrameAt *time.Time, now func() time.Time, acquire acquireFrameFn, timeoutMs uint) (*d3d11.ID3D11Texture2D, dxgi.DXGI_OUTDUPL_FRAME_INFO, func(), bool, error) {
+	if cfg.TargetInterval <= 0 {
+		desktop2d, frameInfo, cleanup, err := acquire(timeoutMs)
+		if err != nil {
+			if errors.Is(err, ErrNoImageYet) {
+				stats.FramesTimedOut++
+			}
+			return nil, frameInfo, nil, false, err
+		}
+		stats.FramesAcquired++
+		*lastFrameAt = now()
+		*haveAnyFrame = true
+		return desktop2d, frameInfo, cleanup, false, nil
+	}
+
+	poll := cfg.PollInterval
+	if poll <= 0 {
+		poll = 8 * time.Millisecond
+	}
+	deadline := now().Add(cfg.TargetInterval)
+	for {
+		desktop2d, frameInfo, cleanup, err := acquire(uint(poll.Milliseconds()))
+		if err == nil {
+			stats.FramesAcquired++
+			*lastFrameAt = now()
+			*haveAnyFrame = true
+			return desktop2d, frameInfo, cleanup, false, nil
+		}
+		if !errors.Is(err, ErrNoImageYet) {
+			return nil, frameInfo, nil, false, err
+		}
+		stats.FramesTimedOut++
+
+		if cfg.ForceRefreshTimeout > 0 && *haveAnyFrame &&
+			now().Sub(*lastFrameAt) >= cfg.ForceRefreshTimeout {
+			stats.FramesRepeated++
+			return nil, dxgi.DXGI_OUTDUPL_FRAME_INFO{}, func() {}, true, nil
+		}
+
+		if now().After(deadline) {
+			stats.FramesDropped++
+			return nil, frameInfo, nil, false, ErrNoImageYet
+		}
+	}
+}
+
+// returns DXGI_FORMAT_B8G8R8A8_UNORM data
+func (dup *OutputDuplicator) Snapshot(timeoutMs uint) (unmapFn, *dxgi.DXGI_MAPPED_RECT, *dxgi.POINT, error) {
+	var hr int32
+	desc := dxgi.DXGI_OUTDUPL_DESC{}
+	hr = dup.outputDuplication.GetDesc(&desc)
+	if hr := d3d.HRESULT(hr); hr.Failed() {
+		return nil, nil, nil, fmt.Errorf("failed to get the description. %w", hr)
+	}
+
+	if desc.DesktopImageInSystemMemory != 0 {
+		// TODO: Figure out WHEN exactly this can occur, and if we can make use of it
+		dup.size = dxgi.POINT{X: int32(desc.ModeDesc.Width), Y: int32(desc.ModeDesc.Height)}
+		hr = dup.outputDuplication.MapDesktopSurface(&dup.mappedRect)
+		if hr := d3d.HRESULT(hr); !hr.Failed() {
+			return dup.outputDuplication.UnMapDesktopSurface, &dup.mappedRect, &dup.size, nil
+		}
+	}
+
+	desktop2d, frameInfo, cleanup, repeated, err := dup.acquireFramePaced(timeoutMs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if repeated {
+		// stagedTex already holds the last frame's content untouched; just
+		// re-map it instead of copying anything new.
+		if dup.stagedTex == nil {
+			return nil, nil, nil, ErrNoImageYet
+		}
+		dup.changedRects = dup.changedRects[:0]
+		hr = dup.surface.Map(&dup.mappedRect, dxgi.DXGI_MAP_READ)
+		if hr := d3d.HRESULT(hr); hr.Failed() {
+			return nil, nil, nil, fmt.Errorf("failed to surface_.Map(...). %v", hr)
+		}
+		return dup.surface.Unmap, &dup.mappedRect, &dup.size, nil
+	}
+	defer cleanup()
+
+	if dup.stagedTex == nil {
+		hr = dup.initializeStage(desktop2d)
+		if hr := d3d.HRESULT(hr); hr.Failed() {
+			return nil, nil, nil, fmt.Errorf("failed to InitializeStage. %w", hr)
+		}
+	}
+
+	if err := dup.updateTargetFromFrame(dup.stagedTex, desktop2d, &frameInfo); err != nil {
+		return nil, nil, nil, err
+	}
+
 	hr = dup.surface.Map(&dup.mappedRect, dxgi.DXGI_MAP_READ)
 	if hr := d3d.HRESULT(hr); hr.Failed() {
 		return nil, nil, nil, fmt.Errorf("failed to surface_.Map(...). %v", hr)
@@ -238,6 +561,72 @@ func (dup *OutputDuplicator) Snapshot(timeoutMs uint) (unmapFn, *dxgi.DXGI_MAPPE
 	return dup.surface.Unmap, &dup.mappedRect, &dup.size, nil
 }
 
+// SnapshotTexture acquires the next desktop frame and applies the usual
+// dirty/moved-rect update directly onto an internally owned, GPU-local
+// D3D11_USAGE_DEFAULT texture, skipping the staging copy and Map/memcpy to
+// CPU that Snapshot/GetImage require. The returned texture is owned by dup
+// and stays valid (and stable) until the matching ReleaseTexture call; it
+// must not be written to by the caller. SnapshotTexture returns no handle
+// itself; an NT handle obtained via
+// texture.QueryInterface(dxgi.IID_IDXGIResource1, ...).CreateSharedHandle
+// shares it with another device or process through IDXGIKeyedMutex.
+// Returns an error if DXGI_OUTDUPL_DESC.DesktopImageInSystemMemory is set,
+// since that output has no GPU-resident frame to return; use Snapshot/GetImage
+// instead, the same way Snapshot itself falls back to MapDesktopSurface.
+func (dup *OutputDuplicator) SnapshotTexture(timeoutMs uint) (*d3d11.ID3D11Texture2D, *dxgi.POINT, error) {
+	if dup.textureAcquired {
+		return nil, nil, errors.New("outputduplication: SnapshotTexture called again before ReleaseTexture")
+	}
+
+	var hr int32
+	desc := dxgi.DXGI_OUTDUPL_DESC{}
+	hr = dup.outputDuplication.GetDesc(&desc)
+	if hr := d3d.HRESULT(hr); hr.Failed() {
+		return nil, nil, fmt.Errorf("failed to get the description. %w", hr)
+	}
+	if desc.DesktopImageInSystemMemory != 0 {
+		// Unlike Snapshot, there is no GPU-resident texture to hand back here:
+		// the desktop is only available via MapDesktopSurface into system
+		// memory on this output, so the AcquireNextFrame path below would
+		// silently return stale/incorrect frames instead of the real desktop.
+		return nil, nil, errors.New("outputduplication: SnapshotTexture is unsupported on this output; desktop image is only available in system memory, use Snapshot/GetImage instead")
+	}
+
+	desktop2d, frameInfo, cleanup, err := dup.acquireFrame(timeoutMs)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanup()
+
+	if dup.renderTex == nil {
+		hr = dup.initializeRenderTex(desktop2d)
+		if hr := d3d.HRESULT(hr); hr.Failed() {
+			return nil, nil, fmt.Errorf("failed to initializeRenderTex. %w", hr)
+		}
+	}
+
+	if err := dup.updateTargetFromFrame(dup.renderTex, desktop2d, &frameInfo); err != nil {
+		return nil, nil, err
+	}
+
+	if dup.DrawPointerGPU {
+		if err := dup.drawPointerGPU(dup.renderTex); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	dup.textureAcquired = true
+	return dup.renderTex, &dup.size, nil
+}
+
+// ReleaseTexture marks the texture returned by SnapshotTexture as no longer
+// in use by the caller. It is the GPU-resident counterpart of ReleaseFrame
+// and should be called once the caller is done reading from (or has queued
+// GPU work sampling) the texture, before the next SnapshotTexture call.
+func (dup *OutputDuplicator) ReleaseTexture() {
+	dup.textureAcquired = false
+}
+
 func (dup *OutputDuplicator) DrawCursor(img *image.RGBA) error {
 	return dup.drawPointer(img)
 }
@@ -305,6 +694,8 @@ func (dup *OutputDuplicator) updatePointer(info *dxgi.DXGI_OUTDUPL_FRAME_INFO) e
 			dup.pointerInfo.shapeOutBuffer.Pix = make([]byte, neededSize)
 		}
 
+		dup.pointerInfo.isMonochrome = pointerInfo.Type == dxgi.DXGI_OUTDUPL_POINTER_SHAPE_TYPE_MONOCHROME
+
 		switch pointerInfo.Type {
 		case dxgi.DXGI_OUTDUPL_POINTER_SHAPE_TYPE_MONOCHROME:
 			width := int(pointerInfo.Width)
@@ -548,6 +939,12 @@ func newIDXGIOutputDuplicationFormat(device *d3d11.ID3D11Device, deviceCtx *d3d1
 		needsSwizzle = true
 		// fancy stuff not supported :/
 		// fmt.Printf("Info: failed to use dxgiOutput5.DuplicateOutput1, falling back to dxgiOutput1.DuplicateOutput. Missing manifest with DPI awareness set to \"PerMonitorV2\"? %v\n", _DXGI_ERROR(hr))
+		if format != dxgi.DXGI_FORMAT_R8G8B8A8_UNORM {
+			// DuplicateOutput (no "1" suffix) only ever hands back DXGI_FORMAT_B8G8R8A8_UNORM.
+			// Silently falling back here would hand HDR callers clipped SDR data, so fail loudly instead.
+			dxgiOutput5.Release()
+			return nil, fmt.Errorf("format %v requires IDXGIOutput5.DuplicateOutput1, which is unavailable on this output/adapter: %w", format, d3d.HRESULT(hr))
+		}
 		var dxgiOutput1 *dxgi.IDXGIOutput1
 		hr := dxgiOutput.QueryInterface(dxgi.IID_IDXGIOutput1, &dxgiOutput1)
 		if hr := d3d.HRESULT(hr); hr.Failed() {
@@ -562,10 +959,41 @@ func newIDXGIOutputDuplicationFormat(device *d3d11.ID3D11Device, deviceCtx *d3d1
 		}
 	}
 
-	return &OutputDuplicator{device: device, deviceCtx: deviceCtx, outputDuplication: dup, needsSwizzle: needsSwizzle, dxgiOutput: dxgiOutput5}, nil
+	return &OutputDuplicator{
+		device:            device,
+		deviceCtx:         deviceCtx,
+		outputDuplication: dup,
+		needsSwizzle:      needsSwizzle,
+		dxgiOutput:        dxgiOutput5,
+		pixelFormat:       format,
+	}, nil
 }
 
 // NewIDXGIOutputDuplication creates a new OutputDuplicator
 func NewIDXGIOutputDuplication(device *d3d11.ID3D11Device, deviceCtx *d3d11.ID3D11DeviceContext, output uint) (*OutputDuplicator, error) {
 	return newIDXGIOutputDuplicationFormat(device, deviceCtx, output, dxgi.DXGI_FORMAT_R8G8B8A8_UNORM)
 }
+
+// NewIDXGIOutputDuplicationHDR creates an OutputDuplicator that captures
+// DXGI_FORMAT_R16G16B16A16_FLOAT (scRGB) frames instead of 8-bit BGRA, for
+// HDR/wide-gamut desktops. It requires IDXGIOutput5.DuplicateOutput1 support
+// and returns an error, rather than falling back to 8-bit DuplicateOutput,
+// where that is unavailable.
+// Read frames back with GetImageFloat or GetImageNRGBA64, not GetImage.
+func NewIDXGIOutputDuplicationHDR(device *d3d11.ID3D11Device, deviceCtx *d3d11.ID3D11DeviceContext, output uint, toneMap ToneMapMode) (*OutputDuplicator, error) {
+	dup, err := newIDXGIOutputDuplicationFormat(device, deviceCtx, output, dxgi.DXGI_FORMAT_R16G16B16A16_FLOAT)
+	if err != nil {
+		return nil, err
+	}
+	dup.ToneMap = toneMap
+	return dup, nil
+}
+
+// NewIDXGIOutputDuplicationFormat creates an OutputDuplicator requesting a
+// specific DXGI_FORMAT from DuplicateOutput1. Only DXGI_FORMAT_R8G8B8A8_UNORM
+// and DXGI_FORMAT_R16G16B16A16_FLOAT are exercised by the rest of this
+// package (GetImage and GetImageFloat/GetImageNRGBA64 respectively); other
+// formats will duplicate fine but have no matching readback path.
+func NewIDXGIOutputDuplicationFormat(device *d3d11.ID3D11Device, deviceCtx *d3d11.ID3D11DeviceContext, output uint, format dxgi.DXGI_FORMAT) (*OutputDuplicator, error) {
+	return newIDXGIOutputDuplicationFormat(device, deviceCtx, output, format)
+}